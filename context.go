@@ -47,7 +47,23 @@ func (c *Context) Use(h ...Handler) {
  * Create a route
  */
 func (c *Context) HandleFunc(u string, f func(http.ResponseWriter, *Request, Pipeline)(interface{}, error), a ...Attrs) *mux.Route {
-  return c.Handle(u, c.pipeline.Add(HandlerFunc(f)), a...)
+  return c.Handle(u, c.handler(HandlerFunc(f)), a...)
+}
+
+/**
+ * Produce the handler for a route. The result of the route handler is
+ * finished, by waiting for detached requests and generating the locations of
+ * created resources, before it is returned through the context pipeline.
+ */
+func (c *Context) handler(h Handler) Handler {
+  return c.pipeline.Add(HandlerFunc(func(rsp http.ResponseWriter, req *Request, pln Pipeline) (interface{}, error) {
+    res, err := h.ServeRequest(rsp, req, pln)
+    res, err = req.await(res, err, c.service.detachTimeout)
+    if err != nil {
+      return res, err
+    }
+    return c.service.resolveLocation(res)
+  }))
 }
 
 /**
@@ -55,6 +71,11 @@ func (c *Context) HandleFunc(u string, f func(http.ResponseWriter, *Request, Pip
  */
 func (c *Context) Handle(u string, h Handler, a ...Attrs) *mux.Route {
   attr := mergeAttrs(a...)
+  if v, ok := attr[AttrDefaultStatus]; ok {
+    if s, ok := v.(int); !ok || s < 200 || s > 299 {
+      panic(fmt.Sprintf("rest: %v must be a 2xx status (int); got %T %v", AttrDefaultStatus, v, v))
+    }
+  }
   return c.router.HandleFunc(u, func(rsp http.ResponseWriter, req *http.Request){
     c.handle(rsp, newRequestWithAttributes(req, attr), h)
   })
@@ -122,6 +143,7 @@ func (c *Context) handle(rsp http.ResponseWriter, req *Request, h Handler) {
   
   // handle the request itself and finalize if needed
   res, err := h.ServeRequest(rsp, req, nil)
+  res, err = req.await(res, err, c.service.detachTimeout)
   if (req.flags & reqFlagFinalized) != reqFlagFinalized {
     c.service.sendResponse(rsp, req, res, err)
     alt.Debugf("%s: [%v] (%v) %s %s", c.service.name, req.Id, time.Since(start), req.Method, where)
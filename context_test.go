@@ -0,0 +1,147 @@
+package rest
+
+import (
+  "testing"
+  "net/http"
+  "net/http/httptest"
+)
+
+func TestContextDefaultStatus(t *testing.T) {
+  s := NewService(Config{})
+  c := s.Context()
+  
+  entity := func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+    return map[string]string{"a": "b"}, nil
+  }
+  empty := func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+    return nil, nil
+  }
+  explicit := func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+    return NewResponse(http.StatusAccepted, nil, "explicit"), nil
+  }
+  
+  c.HandleFunc("/none", entity)
+  c.HandleFunc("/created", entity, Attrs{AttrDefaultStatus: http.StatusCreated})
+  c.HandleFunc("/empty", empty, Attrs{AttrDefaultStatus: http.StatusCreated})
+  c.HandleFunc("/deleted", entity, Attrs{AttrDefaultStatus: http.StatusNoContent})
+  c.HandleFunc("/reset", entity, Attrs{AttrDefaultStatus: http.StatusResetContent})
+  c.HandleFunc("/explicit", explicit, Attrs{AttrDefaultStatus: http.StatusCreated})
+  
+  tests := []struct {
+    Path    string
+    Status  int
+    Entity  string
+    CType   string
+  }{
+    {"/none", http.StatusOK, `{"a":"b"}`, "application/json"},
+    {"/created", http.StatusCreated, `{"a":"b"}`, "application/json"},
+    {"/empty", http.StatusCreated, "", ""},
+    {"/deleted", http.StatusNoContent, "", ""},
+    {"/reset", http.StatusResetContent, "", ""},
+    {"/explicit", http.StatusAccepted, `"explicit"`, "application/json"},
+  }
+  for _, e := range tests {
+    rsp := httptest.NewRecorder()
+    s.ServeHTTP(rsp, httptest.NewRequest("GET", e.Path, nil))
+    if rsp.Code != e.Status {
+      t.Errorf("%v: unexpected status: %v", e.Path, rsp.Code)
+    }
+    if b := rsp.Body.String(); b != e.Entity {
+      t.Errorf("%v: unexpected entity: %v", e.Path, b)
+    }
+    if v := rsp.Header().Get("Content-Type"); v != e.CType {
+      t.Errorf("%v: unexpected content type: %v", e.Path, v)
+    }
+  }
+}
+
+func TestContextDefaultStatusInvalid(t *testing.T) {
+  c := NewService(Config{}).Context()
+  h := func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+    return nil, nil
+  }
+  for _, e := range []interface{}{"201", int64(201), 0, 301, 404} {
+    func(){
+      defer func(){
+        if recover() == nil {
+          t.Errorf("%T %v: expected a panic", e, e)
+        }
+      }()
+      c.HandleFunc("/invalid", h, Attrs{AttrDefaultStatus: e})
+    }()
+  }
+}
+
+func TestContextCreated(t *testing.T) {
+  s := NewService(Config{})
+  
+  var seen http.Header
+  c := s.Context()
+  c.Use(HandlerFunc(func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+    res, err := p.Next(w, r)
+    if v, ok := res.(*Response); ok {
+      seen = v.Headers
+    }
+    return res, err
+  }))
+  
+  b := s.ContextWithBasePath("/v1")
+  b.HandleFunc("/things/{id}", func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+    return nil, nil
+  }).Name("thing")
+  c.HandleFunc("/widgets/{id}", func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+    return nil, nil
+  }).Name("widget")
+  
+  shared := Created("entity", "thing", "id", "123").Header("X-Example", "Value")
+  c.HandleFunc("/shared", func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+    return shared, nil
+  })
+  c.HandleFunc("/widget", func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+    return Created("entity", "widget", "id", "456"), nil
+  })
+  c.HandleFunc("/unknown", func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+    return Created("entity", "unknown", "id", "123"), nil
+  })
+  c.HandleFunc("/unpaired", func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+    return Created("entity", "thing", "id"), nil
+  })
+  
+  tests := []struct {
+    Path      string
+    Status    int
+    Location  string
+  }{
+    {"/shared", http.StatusCreated, "/v1/things/123"},
+    {"/shared", http.StatusCreated, "/v1/things/123"},
+    {"/widget", http.StatusCreated, "/widgets/456"},
+    {"/unknown", http.StatusInternalServerError, ""},
+    {"/unpaired", http.StatusInternalServerError, ""},
+  }
+  for _, e := range tests {
+    seen = nil
+    rsp := httptest.NewRecorder()
+    s.ServeHTTP(rsp, httptest.NewRequest("POST", e.Path, nil))
+    if rsp.Code != e.Status {
+      t.Errorf("%v: unexpected status: %v", e.Path, rsp.Code)
+    }
+    if v := rsp.Header()["Location"]; e.Location != "" && (len(v) != 1 || v[0] != e.Location) {
+      t.Errorf("%v: unexpected location: %v", e.Path, v)
+    }
+    if e.Status == http.StatusCreated {
+      if b := rsp.Body.String(); b != `"entity"` {
+        t.Errorf("%v: unexpected entity: %v", e.Path, b)
+      }
+      if v := seen.Get("Location"); v != e.Location {
+        t.Errorf("%v: middleware did not observe the location: %v", e.Path, v)
+      }
+    }
+  }
+  
+  if v := shared.Headers.Get("Location"); v != "" {
+    t.Errorf("Shared response was modified: %v", v)
+  }
+  if v := shared.Headers.Get("X-Example"); v != "Value" {
+    t.Errorf("Shared response was modified: %v", v)
+  }
+}
@@ -11,26 +11,66 @@ import (
 // A response
 type Response struct {
   StatusCode  int
-  Headers     map[string]string
+  Headers     http.Header
   Entity      interface{}
 }
 
 // Create an entity context wrapper
 func NewResponse(r int, h map[string]string, e interface{}) *Response {
-  return &Response{r, h, e}
+  var hdr http.Header
+  if h != nil {
+    hdr = make(http.Header)
+    for k, v := range h {
+      hdr.Set(k, v)
+    }
+  }
+  return &Response{r, hdr, e}
 }
 
 // Create a redirect response
 func NewRedirect(loc string) *Response {
-  return &Response{http.StatusFound, map[string]string{"Location": loc}, nil}
+  return &Response{http.StatusFound, http.Header{"Location": []string{loc}}, nil}
+}
+
+// A created entity whose location is generated from a named route
+type createdEntity struct {
+  entity  interface{}
+  route   string
+  vars    []string
+}
+
+// Create a response for a newly created resource. The Location header is
+// generated from the named route and its variables, provided as key/value
+// pairs, as soon as the route handler returns, so middleware observes the
+// response with its location set. If the location cannot be generated the
+// request fails with 500 Internal Server Error.
+func Created(e interface{}, name string, vars ...string) *Response {
+  return &Response{http.StatusCreated, nil, createdEntity{e, name, vars}}
 }
 
-// Set a header value
+// Set a header value, replacing any existing values
 func (r *Response) Header(k, v string) *Response {
   if r.Headers == nil {
-    r.Headers = make(map[string]string)
+    r.Headers = make(http.Header)
+  }
+  r.Headers.Set(k, v)
+  return r
+}
+
+// Add a header value, retaining any existing values
+func (r *Response) AddHeader(k, v string) *Response {
+  if r.Headers == nil {
+    r.Headers = make(http.Header)
+  }
+  r.Headers.Add(k, v)
+  return r
+}
+
+// Add a cookie; this may be called multiple times to set several cookies
+func (r *Response) SetCookie(c *http.Cookie) *Response {
+  if v := c.String(); v != "" {
+    r.AddHeader("Set-Cookie", v)
   }
-  r.Headers[k] = v
   return r
 }
 
@@ -93,6 +133,13 @@ func (e BytesEntity) ContentType() string {
 // An entity handler
 type EntityHandler func(http.ResponseWriter, *Request, int, interface{})(error)
 
+// Set the content type of a response unless one has been set explicitly
+func setContentType(rsp http.ResponseWriter, t string) {
+  if rsp.Header().Get("Content-Type") == "" {
+    rsp.Header().Set("Content-Type", t)
+  }
+}
+
 // The default entity handler
 func DefaultEntityHandler(rsp http.ResponseWriter, req *Request, status int, content interface{}) error {
   switch e := content.(type) {
@@ -104,7 +151,7 @@ func DefaultEntityHandler(rsp http.ResponseWriter, req *Request, status int, con
       // do nothing; the response is handled externally
     
     case Entity:
-      rsp.Header().Add("Content-Type", e.ContentType())
+      setContentType(rsp, e.ContentType())
       rsp.WriteHeader(status)
       
       n, err := io.Copy(rsp, e)
@@ -113,7 +160,7 @@ func DefaultEntityHandler(rsp http.ResponseWriter, req *Request, status int, con
       }
       
     case json.RawMessage:
-      rsp.Header().Add("Content-Type", "application/json")
+      setContentType(rsp, "application/json")
       rsp.WriteHeader(status)
       
       _, err := rsp.Write([]byte(e))
@@ -122,7 +169,7 @@ func DefaultEntityHandler(rsp http.ResponseWriter, req *Request, status int, con
       }
       
     default:
-      rsp.Header().Add("Content-Type", "application/json")
+      setContentType(rsp, "application/json")
       rsp.WriteHeader(status)
       
       data, err := json.Marshal(content)
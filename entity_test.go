@@ -0,0 +1,34 @@
+package rest
+
+import (
+  "testing"
+  "net/http"
+)
+
+func TestResponseSetCookie(t *testing.T) {
+  r := NewResponse(http.StatusOK, map[string]string{"X-Example": "Value"}, nil)
+  r.SetCookie(&http.Cookie{Name: "a", Value: "1"})
+  r.SetCookie(&http.Cookie{Name: "b", Value: "2", Path: "/"})
+  r.SetCookie(&http.Cookie{Name: "invalid name", Value: "3"})
+  
+  c := r.Headers["Set-Cookie"]
+  if len(c) != 2 {
+    t.Fatalf("Unexpected cookies: %v", c)
+  }
+  if c[0] != "a=1" || c[1] != "b=2; Path=/" {
+    t.Errorf("Unexpected cookies: %v", c)
+  }
+  if v := r.Headers.Get("X-Example"); v != "Value" {
+    t.Errorf("Unexpected header: %v", v)
+  }
+}
+
+func TestResponseHeaders(t *testing.T) {
+  r := (&Response{}).AddHeader("Link", "<a>").AddHeader("Link", "<b>").Header("X-Example", "A").Header("X-Example", "B")
+  if v := r.Headers["Link"]; len(v) != 2 || v[0] != "<a>" || v[1] != "<b>" {
+    t.Errorf("Unexpected header: %v", v)
+  }
+  if v := r.Headers["X-Example"]; len(v) != 1 || v[0] != "B" {
+    t.Errorf("Unexpected header: %v", v)
+  }
+}
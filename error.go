@@ -10,7 +10,7 @@ import (
  */
 type Error struct {
   Status    int
-  Headers   map[string]string
+  Headers   http.Header
   Cause     error
 }
 
@@ -29,13 +29,50 @@ func NewErrorf(s int, f string, a ...interface{}) *Error {
 }
 
 /**
- * Set headers
+ * Set headers, replacing any existing headers
  */
 func (e *Error) SetHeaders(h map[string]string) *Error {
+  var hdr http.Header
+  if h != nil {
+    hdr = make(http.Header)
+    for k, v := range h {
+      hdr.Set(k, v)
+    }
+  }
+  e.Headers = hdr
+  return e
+}
+
+/**
+ * Set headers which may have multiple values, replacing any existing headers
+ */
+func (e *Error) SetHeaderValues(h http.Header) *Error {
   e.Headers = h
   return e
 }
 
+/**
+ * Set a header value, replacing any existing values
+ */
+func (e *Error) Header(k, v string) *Error {
+  if e.Headers == nil {
+    e.Headers = make(http.Header)
+  }
+  e.Headers.Set(k, v)
+  return e
+}
+
+/**
+ * Add a header value, retaining any existing values
+ */
+func (e *Error) AddHeader(k, v string) *Error {
+  if e.Headers == nil {
+    e.Headers = make(http.Header)
+  }
+  e.Headers.Add(k, v)
+  return e
+}
+
 /**
  * Obtain the error message
  */
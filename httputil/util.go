@@ -1,6 +1,7 @@
 package httputil
 
 import (
+  "io"
   "strings"
   "net/http"
   "io/ioutil"
@@ -72,3 +73,67 @@ func CopyRequest(r *http.Request) *http.Request {
   
   return d
 }
+
+// Headers which carry distributed tracing context and are propagated to
+// derived requests when present on the incoming request
+var traceHeaders = []string{
+  "Traceparent",
+  "Tracestate",
+  "X-B3-Traceid",
+  "X-B3-Spanid",
+  "X-B3-Parentspanid",
+  "X-B3-Sampled",
+  "X-B3-Flags",
+  "X-Cloud-Trace-Context",
+  "X-Amzn-Trace-Id",
+}
+
+// Options controlling how an outbound request is derived from an incoming one
+type DeriveOptions struct {
+  ForwardAuthorization  bool      // copy the incoming Authorization header; only enable this for trusted hosts
+  Headers               []string  // additional incoming headers to copy
+}
+
+// Create an outbound request on behalf of an incoming request using the
+// default options, which do not forward authorization. See DeriveWithOptions.
+func Derive(req *rest.Request, method, url string) (*http.Request, error) {
+  return DeriveWithOptions(req, method, url, nil, DeriveOptions{})
+}
+
+// Create an outbound request on behalf of an incoming request. The derived
+// request shares the incoming request's context, and therefore its deadline
+// and cancelation, carries the incoming request ID as X-Request-Id, and
+// propagates tracing headers and, if configured, authorization.
+func DeriveWithOptions(req *rest.Request, method, url string, body io.Reader, opts DeriveOptions) (*http.Request, error) {
+  
+  out, err := http.NewRequest(method, url, body)
+  if err != nil {
+    return nil, err
+  }
+  
+  out = out.WithContext(req.Context())
+  out.Header.Set("X-Request-Id", req.Id)
+  
+  for _, e := range traceHeaders {
+    copyHeader(out.Header, req.Header, e)
+  }
+  if opts.ForwardAuthorization {
+    copyHeader(out.Header, req.Header, "Authorization")
+  }
+  for _, e := range opts.Headers {
+    copyHeader(out.Header, req.Header, e)
+  }
+  
+  return out, nil
+}
+
+// Copy a header which is not already set
+func copyHeader(dst, src http.Header, k string) {
+  k = http.CanonicalHeaderKey(k)
+  if _, ok := dst[k]; ok {
+    return
+  }
+  for _, v := range src[k] {
+    dst.Add(k, v)
+  }
+}
@@ -0,0 +1,91 @@
+package httputil
+
+import (
+  "time"
+  "context"
+  "testing"
+  "net/http/httptest"
+)
+
+import (
+  "github.com/bww/go-rest"
+)
+
+func incoming() (*rest.Request, context.CancelFunc) {
+  cxt, cancel := context.WithTimeout(context.Background(), time.Minute)
+  r := httptest.NewRequest("GET", "/", nil).WithContext(cxt)
+  r.Header.Set("Authorization", "Bearer secret")
+  r.Header.Set("Traceparent", "00-trace-span-01")
+  r.Header.Add("X-B3-Sampled", "1")
+  r.Header.Set("X-Request-Id", "upstream")
+  r.Header.Set("X-Example", "Value")
+  return &rest.Request{Request: r, Id: "request-id"}, cancel
+}
+
+func TestDerive(t *testing.T) {
+  req, cancel := incoming()
+  defer cancel()
+  
+  out, err := Derive(req, "POST", "https://example.com/things")
+  if err != nil {
+    t.Fatal(err)
+  }
+  
+  if out.Method != "POST" || out.URL.String() != "https://example.com/things" {
+    t.Errorf("Unexpected request: %v %v", out.Method, out.URL)
+  }
+  if out.Context() != req.Context() {
+    t.Errorf("Context was not inherited")
+  }
+  if d, ok := out.Context().Deadline(); !ok {
+    t.Errorf("Deadline was not inherited")
+  }else if e, _ := req.Context().Deadline(); !d.Equal(e) {
+    t.Errorf("Unexpected deadline: %v", d)
+  }
+  if v := out.Header["X-Request-Id"]; len(v) != 1 || v[0] != "request-id" {
+    t.Errorf("Unexpected request ID: %v", v)
+  }
+  if v := out.Header.Get("Traceparent"); v != "00-trace-span-01" {
+    t.Errorf("Unexpected trace header: %v", v)
+  }
+  if v := out.Header.Get("X-B3-Sampled"); v != "1" {
+    t.Errorf("Unexpected trace header: %v", v)
+  }
+  if v := out.Header.Get("Authorization"); v != "" {
+    t.Errorf("Authorization was forwarded by default: %v", v)
+  }
+  if v := out.Header.Get("X-Example"); v != "" {
+    t.Errorf("Unexpected header was copied: %v", v)
+  }
+  
+  cancel()
+  if out.Context().Err() == nil {
+    t.Errorf("Cancelation was not inherited")
+  }
+}
+
+func TestDeriveWithOptions(t *testing.T) {
+  req, cancel := incoming()
+  defer cancel()
+  
+  out, err := DeriveWithOptions(req, "GET", "https://example.com/things", nil, DeriveOptions{
+    ForwardAuthorization: true,
+    Headers: []string{"x-example", "X-Request-Id", "Traceparent", "Authorization"},
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+  
+  if v := out.Header["Authorization"]; len(v) != 1 || v[0] != "Bearer secret" {
+    t.Errorf("Unexpected authorization: %v", v)
+  }
+  if v := out.Header["X-Example"]; len(v) != 1 || v[0] != "Value" {
+    t.Errorf("Unexpected header: %v", v)
+  }
+  if v := out.Header["X-Request-Id"]; len(v) != 1 || v[0] != "request-id" {
+    t.Errorf("Unexpected request ID: %v", v)
+  }
+  if v := out.Header["Traceparent"]; len(v) != 1 {
+    t.Errorf("Unexpected trace header: %v", v)
+  }
+}
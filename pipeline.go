@@ -2,6 +2,7 @@ package rest
 
 import (
   "fmt"
+  "sync"
   "time"
   "strings"
   "net/http"
@@ -18,6 +19,19 @@ import (
  */
 type Attrs map[string]interface{}
 
+/**
+ * Attribute keys recognized by the service. A route may declare the status
+ * used for successful responses which do not specify their own status, e.g.:
+ *
+ *   c.HandleFunc("/things", create, rest.Attrs{rest.AttrDefaultStatus: http.StatusCreated})
+ *
+ * The status must be a 2xx int; registering a route with any other value
+ * panics. No entity is sent with statuses which do not permit a body (204).
+ */
+const (
+  AttrDefaultStatus = "rest.status.default"
+)
+
 /**
  * Merge attributes
  */
@@ -41,6 +55,7 @@ type requestFlags uint32
 const (
   reqFlagNone         = 0
   reqFlagFinalized    = 1 << 0
+  reqFlagCompleted    = 1 << 1
 )
 
 /**
@@ -54,6 +69,7 @@ type Request struct {
   Traces  []trace.Trace
   flags   requestFlags
   start   time.Time
+  detach  *Completer
 }
 
 /**
@@ -67,7 +83,7 @@ func newRequest(r *http.Request) *Request {
  * Create a service request
  */
 func newRequestWithAttributes(r *http.Request, a Attrs) *Request {
-  return &Request{r, uuid.Time().String(), a, nil, nil, 0, time.Now()}
+  return &Request{r, uuid.Time().String(), a, nil, nil, 0, time.Now(), nil}
 }
 
 /**
@@ -83,6 +99,17 @@ func (r *Request) putAttributes(a Attrs) {
   }
 }
 
+/**
+ * Obtain the status to use for a successful response which does not provide
+ * one explicitly
+ */
+func (r *Request) defaultStatus() int {
+  if v, ok := r.Attrs[AttrDefaultStatus].(int); ok && v > 0 {
+    return v
+  }
+  return http.StatusOK
+}
+
 /**
  * Finalize the request
  */
@@ -90,6 +117,90 @@ func (r *Request) Finalize() {
   r.flags |= reqFlagFinalized
 }
 
+/**
+ * Detach the request so its response can be completed from another goroutine
+ */
+func (r *Request) Detach() *Completer {
+  if r.detach == nil {
+    r.detach = newCompleter()
+  }
+  return r.detach
+}
+
+/**
+ * If the request was detached, wait for it to be completed and return its
+ * result, otherwise return the result provided by the handler. A timeout of
+ * zero or less waits indefinitely for completion or cancelation.
+ */
+func (r *Request) await(res interface{}, err error, timeout time.Duration) (interface{}, error) {
+  c := r.detach
+  if c == nil || (r.flags & reqFlagCompleted) == reqFlagCompleted {
+    return res, err
+  }
+  r.flags |= reqFlagCompleted
+  
+  // the handler failed after detaching; any later completion is ignored
+  if err != nil {
+    c.Complete(nil, err)
+    return res, err
+  }
+  
+  var expire <-chan time.Time
+  if timeout > 0 {
+    t := time.NewTimer(timeout)
+    defer t.Stop()
+    expire = t.C
+  }
+  
+  select {
+    case v := <-c.result:
+      return v.res, v.err
+    case <-expire:
+      err = NewErrorf(http.StatusServiceUnavailable, "Detached request was not completed within %v", timeout)
+    case <-r.Context().Done():
+      err = NewErrorf(http.StatusServiceUnavailable, "Detached request was not completed: %v", r.Context().Err())
+  }
+  
+  c.Complete(nil, err) // any later completion is ignored
+  return nil, err
+}
+
+/**
+ * The result of a detached request
+ */
+type completion struct {
+  res interface{}
+  err error
+}
+
+/**
+ * Completes a detached request
+ */
+type Completer struct {
+  result  chan completion
+  once    sync.Once
+}
+
+/**
+ * Create a completer
+ */
+func newCompleter() *Completer {
+  return &Completer{result: make(chan completion, 1)}
+}
+
+/**
+ * Complete the request with a result, which is returned through the pipeline
+ * as if the handler had returned it. Once a request is detached, a successful
+ * result returned by its handler is ignored but an error is used instead.
+ * Only the first completion has any effect; completing a request that has
+ * already failed, timed out or been canceled by the client does nothing.
+ */
+func (c *Completer) Complete(res interface{}, err error) {
+  c.once.Do(func(){
+    c.result <- completion{res, err}
+  })
+}
+
 /**
  * Obtain the start / creation time of the request
  */
@@ -0,0 +1,126 @@
+package rest
+
+import (
+  "time"
+  "errors"
+  "context"
+  "testing"
+  "net/http"
+  "net/http/httptest"
+)
+
+func serveDetached(c *Context, r *http.Request, f HandlerFunc) *httptest.ResponseRecorder {
+  rsp := httptest.NewRecorder()
+  c.handle(rsp, newRequest(r), c.handler(f))
+  return rsp
+}
+
+func TestDetachComplete(t *testing.T) {
+  c := NewService(Config{}).Context()
+  
+  var seen interface{}
+  c.Use(HandlerFunc(func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+    res, err := p.Next(w, r)
+    seen = res
+    return res, err
+  }))
+  
+  rsp := serveDetached(c, httptest.NewRequest("GET", "/", nil), func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+    x := r.Detach()
+    go func(){
+      time.Sleep(10 * time.Millisecond)
+      x.Complete(NewResponse(http.StatusAccepted, nil, "completed"), nil)
+    }()
+    return "ignored", nil
+  })
+  
+  if rsp.Code != http.StatusAccepted {
+    t.Errorf("Unexpected status: %v", rsp.Code)
+  }
+  if b := rsp.Body.String(); b != `"completed"` {
+    t.Errorf("Unexpected entity: %v", b)
+  }
+  if v, ok := seen.(*Response); !ok || v.Entity != "completed" {
+    t.Errorf("Middleware did not observe the completed result: %v", seen)
+  }
+}
+
+func TestDetachCompleteTwice(t *testing.T) {
+  c := NewService(Config{}).Context()
+  
+  rsp := serveDetached(c, httptest.NewRequest("GET", "/", nil), func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+    x := r.Detach()
+    if r.Detach() != x {
+      t.Errorf("Detaching twice produced different completers")
+    }
+    x.Complete("first", nil)
+    x.Complete("second", nil)
+    return nil, nil
+  })
+  
+  if rsp.Code != http.StatusOK {
+    t.Errorf("Unexpected status: %v", rsp.Code)
+  }
+  if b := rsp.Body.String(); b != `"first"` {
+    t.Errorf("Unexpected entity: %v", b)
+  }
+}
+
+func TestDetachHandlerError(t *testing.T) {
+  c := NewService(Config{}).Context()
+  
+  var x *Completer
+  var rsp *httptest.ResponseRecorder
+  done := make(chan struct{})
+  go func(){
+    rsp = serveDetached(c, httptest.NewRequest("GET", "/", nil), func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+      x = r.Detach()
+      return nil, NewError(http.StatusBadRequest, errors.New("Invalid"))
+    })
+    close(done)
+  }()
+  
+  select {
+    case <-done:
+    case <-time.After(time.Second):
+      t.Fatalf("Handler error did not complete the detached request")
+  }
+  if rsp.Code != http.StatusBadRequest {
+    t.Errorf("Unexpected status: %v", rsp.Code)
+  }
+  
+  x.Complete("late", nil) // must not block
+}
+
+func TestDetachCanceled(t *testing.T) {
+  c := NewService(Config{}).Context()
+  
+  cxt, cancel := context.WithCancel(context.Background())
+  req := httptest.NewRequest("GET", "/", nil).WithContext(cxt)
+  
+  rsp := serveDetached(c, req, func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+    r.Detach()
+    go cancel()
+    return nil, nil
+  })
+  
+  if rsp.Code != http.StatusServiceUnavailable {
+    t.Errorf("Unexpected status: %v", rsp.Code)
+  }
+}
+
+func TestDetachTimeout(t *testing.T) {
+  c := NewService(Config{DetachTimeout: 10 * time.Millisecond}).Context()
+  
+  var x *Completer
+  rsp := serveDetached(c, httptest.NewRequest("GET", "/", nil), func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+    x = r.Detach()
+    return nil, nil
+  })
+  
+  if rsp.Code != http.StatusServiceUnavailable {
+    t.Errorf("Unexpected status: %v", rsp.Code)
+  }
+  
+  x.Complete("late", nil) // must not block
+}
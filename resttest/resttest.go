@@ -0,0 +1,159 @@
+// Package resttest provides scenarios which exercise the middleware stack
+// of a service, either in-process or against a running deployment, e.g.:
+//
+//   suite := resttest.Suite{
+//     resttest.Unauthenticated(resttest.Request{Method: "GET", Path: "/v1/things"}),
+//     resttest.MalformedEntity(resttest.Request{Method: "POST", Path: "/v1/things"}),
+//   }
+//   suite.Run(t, resttest.NewServiceTarget(service))
+//
+package resttest
+
+import (
+  "fmt"
+  "bytes"
+  "strings"
+  "testing"
+  "net/url"
+  "net/http"
+  "net/http/httptest"
+)
+
+import (
+  "github.com/bww/go-rest"
+)
+
+// A target which scenarios are run against
+type Target interface {
+  Do(*http.Request)(*http.Response, error)
+}
+
+// A target which serves requests in-process
+type handlerTarget struct {
+  handler http.Handler
+}
+
+// Create a target which serves requests with a handler
+func NewHandlerTarget(h http.Handler) Target {
+  return handlerTarget{h}
+}
+
+// Create a target which serves requests through the full pipeline of a service
+func NewServiceTarget(s *rest.Service) Target {
+  return handlerTarget{s.Handler()}
+}
+
+// Serve a request; the request is completed as it would be by a server, in
+// the same way as httptest.NewRequest
+func (t handlerTarget) Do(req *http.Request) (*http.Response, error) {
+  req.RequestURI = req.URL.RequestURI()
+  if req.RemoteAddr == "" {
+    req.RemoteAddr = "192.0.2.1:1234"
+  }
+  if req.Host == "" {
+    req.Host = "example.com"
+  }
+  rec := httptest.NewRecorder()
+  t.handler.ServeHTTP(rec, req)
+  return rec.Result(), nil
+}
+
+// A target which sends requests to a running service
+type remoteTarget struct {
+  base    *url.URL
+  client  *http.Client
+}
+
+// Create a target which sends requests to a service at the provided base URL.
+// If the client is nil, the default client is used.
+func NewRemoteTarget(base string, client *http.Client) (Target, error) {
+  u, err := url.Parse(base)
+  if err != nil {
+    return nil, err
+  }
+  if client == nil {
+    client = http.DefaultClient
+  }
+  return remoteTarget{u, client}, nil
+}
+
+// Send a request; the request path is appended to the base path, so a
+// service mounted under a prefix can be targeted
+func (t remoteTarget) Do(req *http.Request) (*http.Response, error) {
+  u := *t.base
+  u.Path = strings.TrimSuffix(t.base.Path, "/") +"/"+ strings.TrimPrefix(req.URL.Path, "/")
+  u.RawPath = ""
+  u.RawQuery = req.URL.RawQuery
+  u.Fragment = ""
+  req.URL = &u
+  req.Host = u.Host
+  return t.client.Do(req)
+}
+
+// A request to be made by a scenario
+type Request struct {
+  Method  string
+  Path    string
+  Header  http.Header
+  Body    []byte
+}
+
+// The request method, which defaults to GET
+func (r Request) method() string {
+  if r.Method == "" {
+    return "GET"
+  }
+  return r.Method
+}
+
+// Create an HTTP request from this description
+func (r Request) new() (*http.Request, error) {
+  req, err := http.NewRequest(r.method(), r.Path, bytes.NewReader(r.Body))
+  if err != nil {
+    return nil, err
+  }
+  for k, v := range r.Header {
+    for _, e := range v {
+      req.Header.Add(k, e)
+    }
+  }
+  return req, nil
+}
+
+// Describe this request
+func (r Request) String() string {
+  return fmt.Sprintf("%s %s", r.method(), r.Path)
+}
+
+// A scenario
+type Scenario struct {
+  Name  string
+  Run   func(Target)(error)
+}
+
+// A suite of scenarios
+type Suite []Scenario
+
+// Run every scenario in the suite as a subtest
+func (s Suite) Run(t *testing.T, target Target) {
+  for _, e := range s {
+    e := e
+    t.Run(e.Name, func(t *testing.T){
+      if err := e.Run(target); err != nil {
+        t.Error(err)
+      }
+    })
+  }
+}
+
+// Run every scenario in the suite and return the errors produced by those
+// which fail; this is useful for smoke testing outside of go test.
+func (s Suite) Check(target Target) []error {
+  var errs []error
+  for _, e := range s {
+    if err := e.Run(target); err != nil {
+      errs = append(errs, fmt.Errorf("%s: %v", e.Name, err))
+    }
+  }
+  return errs
+}
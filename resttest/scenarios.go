@@ -0,0 +1,127 @@
+package resttest
+
+import (
+  "io"
+  "fmt"
+  "time"
+  "net/http"
+  "io/ioutil"
+)
+
+// Create a scenario which expects the request to produce one of the
+// provided statuses
+func Expect(name string, r Request, status ...int) Scenario {
+  return Scenario{name, func(t Target) error {
+    _, err := expectStatus(t, r, status...)
+    return err
+  }}
+}
+
+// Create a scenario which expects a request that provides no credentials to
+// be rejected as unauthorized. Any Authorization header is removed.
+func Unauthenticated(r Request) Scenario {
+  r.Header = withoutHeader(r.Header, "Authorization")
+  return Expect(fmt.Sprintf("Unauthenticated %v", r), r, http.StatusUnauthorized)
+}
+
+// Create a scenario which expects a request that provides invalid
+// credentials to be rejected
+func BadCredentials(r Request, auth string) Scenario {
+  r.Header = withoutHeader(r.Header, "Authorization")
+  r.Header.Set("Authorization", auth)
+  return Expect(fmt.Sprintf("Bad credentials %v", r), r, http.StatusUnauthorized, http.StatusForbidden)
+}
+
+// Create a scenario which expects a request with an unparsable entity to be
+// rejected as a bad request. The entity is replaced with malformed JSON and,
+// unless one is provided, a JSON content type is used.
+func MalformedEntity(r Request) Scenario {
+  if r.Method == "" {
+    r.Method = "POST"
+  }
+  if r.Header.Get("Content-Type") == "" {
+    r.Header = withoutHeader(r.Header, "Content-Type")
+    r.Header.Set("Content-Type", "application/json")
+  }
+  r.Body = []byte(`{"malformed":`)
+  return Expect(fmt.Sprintf("Malformed entity %v", r), r, http.StatusBadRequest)
+}
+
+// Create a scenario which expects the request to succeed, with a 2xx or 3xx
+// status, up to the provided limit and the next request to be rejected as
+// too many requests. The scenario consumes the limit, so it should be run
+// against a fresh rate limiting window.
+func RateLimited(r Request, limit int) Scenario {
+  return Scenario{fmt.Sprintf("Rate limited %v after %d", r, limit), func(t Target) error {
+    for i := 0; i < limit; i++ {
+      status, err := send(t, r)
+      if err != nil {
+        return err
+      }
+      if status == http.StatusTooManyRequests {
+        return fmt.Errorf("%v: rate limited after %d of %d permitted requests", r, i, limit)
+      }
+      if status < 200 || status > 399 {
+        return fmt.Errorf("%v: permitted request %d of %d failed with status %v", r, i + 1, limit, status)
+      }
+    }
+    _, err := expectStatus(t, r, http.StatusTooManyRequests)
+    return err
+  }}
+}
+
+// Create a scenario which expects a request that does not complete in time
+// to be rejected as unavailable or timed out, and for that response to be
+// produced within the provided duration.
+func TimesOut(r Request, within time.Duration) Scenario {
+  return Scenario{fmt.Sprintf("Times out %v within %v", r, within), func(t Target) error {
+    start := time.Now()
+    _, err := expectStatus(t, r, http.StatusServiceUnavailable, http.StatusGatewayTimeout)
+    if err != nil {
+      return err
+    }
+    if d := time.Since(start); d > within {
+      return fmt.Errorf("%v: timed out after %v; expected within %v", r, d, within)
+    }
+    return nil
+  }}
+}
+
+// Send a request and return its status
+func send(t Target, r Request) (int, error) {
+  req, err := r.new()
+  if err != nil {
+    return 0, err
+  }
+  rsp, err := t.Do(req)
+  if err != nil {
+    return 0, fmt.Errorf("%v: %v", r, err)
+  }
+  defer rsp.Body.Close()
+  io.Copy(ioutil.Discard, rsp.Body)
+  return rsp.StatusCode, nil
+}
+
+// Send a request and verify its status is one of those expected
+func expectStatus(t Target, r Request, expect ...int) (int, error) {
+  status, err := send(t, r)
+  if err != nil {
+    return 0, err
+  }
+  for _, e := range expect {
+    if status == e {
+      return status, nil
+    }
+  }
+  return status, fmt.Errorf("%v: expected status %v; got %v", r, expect, status)
+}
+
+// Copy a header, excluding the named key
+func withoutHeader(h http.Header, k string) http.Header {
+  c := make(http.Header)
+  for n, v := range h {
+    c[n] = append([]string(nil), v...)
+  }
+  c.Del(k)
+  return c
+}
@@ -0,0 +1,255 @@
+package resttest
+
+import (
+  "time"
+  "testing"
+  "net/http"
+  "net/http/httptest"
+)
+
+import (
+  "github.com/bww/go-rest"
+  "github.com/bww/go-rest/httputil"
+)
+
+func TestRateLimited(t *testing.T) {
+  limiter := func(limit int) http.Handler {
+    n := 0
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+      if n++; limit > 0 && n > limit {
+        w.WriteHeader(http.StatusTooManyRequests)
+      }else{
+        w.WriteHeader(http.StatusOK)
+      }
+    })
+  }
+  
+  tests := []struct {
+    Limit   int
+    Expect  int
+    Pass    bool
+  }{
+    {3, 3, true},
+    {2, 3, false}, // limited too early
+    {4, 3, false}, // limited too late
+    {0, 3, false}, // never limited
+  }
+  for _, e := range tests {
+    err := RateLimited(Request{Path: "/"}, e.Expect).Run(NewHandlerTarget(limiter(e.Limit)))
+    if e.Pass && err != nil {
+      t.Errorf("Limit %d, expect %d: unexpected error: %v", e.Limit, e.Expect, err)
+    }else if !e.Pass && err == nil {
+      t.Errorf("Limit %d, expect %d: expected an error", e.Limit, e.Expect)
+    }
+  }
+}
+
+func TestMalformedEntity(t *testing.T) {
+  var method, ctype string
+  h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+    method, ctype = r.Method, r.Header.Get("Content-Type")
+    w.WriteHeader(http.StatusBadRequest)
+  })
+  
+  hdr := http.Header{"X-Example": []string{"Value"}}
+  err := MalformedEntity(Request{Path: "/", Header: hdr}).Run(NewHandlerTarget(h))
+  if err != nil {
+    t.Errorf("Unexpected error: %v", err)
+  }
+  if method != "POST" {
+    t.Errorf("Unexpected method: %v", method)
+  }
+  if ctype != "application/json" {
+    t.Errorf("Unexpected content type: %v", ctype)
+  }
+  if len(hdr) != 1 {
+    t.Errorf("Request header was modified: %v", hdr)
+  }
+  
+  err = MalformedEntity(Request{Method: "PUT", Path: "/", Header: http.Header{"Content-Type": []string{"application/xml"}}}).Run(NewHandlerTarget(h))
+  if err != nil {
+    t.Errorf("Unexpected error: %v", err)
+  }
+  if method != "PUT" {
+    t.Errorf("Unexpected method: %v", method)
+  }
+  if ctype != "application/xml" {
+    t.Errorf("Unexpected content type: %v", ctype)
+  }
+}
+
+func TestUnauthenticated(t *testing.T) {
+  h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+    if r.Header.Get("Authorization") == "" {
+      w.WriteHeader(http.StatusUnauthorized)
+    }else{
+      w.WriteHeader(http.StatusOK)
+    }
+  })
+  
+  hdr := http.Header{"Authorization": []string{"Bearer valid"}}
+  err := Unauthenticated(Request{Path: "/", Header: hdr}).Run(NewHandlerTarget(h))
+  if err != nil {
+    t.Errorf("Unexpected error: %v", err)
+  }
+  if hdr.Get("Authorization") == "" {
+    t.Errorf("Request header was modified: %v", hdr)
+  }
+  
+  err = BadCredentials(Request{Path: "/"}, "Bearer invalid").Run(NewHandlerTarget(h))
+  if err == nil {
+    t.Errorf("Expected an error")
+  }
+}
+
+func TestRemoteTarget(t *testing.T) {
+  var path, query string
+  srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+    path, query = r.URL.Path, r.URL.RawQuery
+  }))
+  defer srv.Close()
+  
+  tests := []struct {
+    Base    string
+    Path    string
+    Expect  string
+  }{
+    {"", "/v1/things?a=b", "/v1/things"},
+    {"/", "/v1/things?a=b", "/v1/things"},
+    {"/api", "/v1/things?a=b", "/api/v1/things"},
+    {"/api/", "/v1/things?a=b", "/api/v1/things"},
+  }
+  for _, e := range tests {
+    target, err := NewRemoteTarget(srv.URL + e.Base, nil)
+    if err != nil {
+      t.Fatal(err)
+    }
+    err = Expect("Remote", Request{Path: e.Path}, http.StatusOK).Run(target)
+    if err != nil {
+      t.Errorf("%v: unexpected error: %v", e.Base, err)
+    }
+    if path != e.Expect || query != "a=b" {
+      t.Errorf("%v: unexpected request: %v?%v", e.Base, path, query)
+    }
+  }
+}
+
+func TestRateLimitedFailure(t *testing.T) {
+  n := 0
+  h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+    if n++; n > 3 {
+      w.WriteHeader(http.StatusTooManyRequests)
+    }else{
+      w.WriteHeader(http.StatusServiceUnavailable)
+    }
+  })
+  if err := RateLimited(Request{Path: "/"}, 3).Run(NewHandlerTarget(h)); err == nil {
+    t.Errorf("Expected an error")
+  }
+}
+
+func TestTimesOut(t *testing.T) {
+  timeout := func(status int, delay time.Duration) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+      time.Sleep(delay)
+      w.WriteHeader(status)
+    })
+  }
+  
+  tests := []struct {
+    Status  int
+    Delay   time.Duration
+    Pass    bool
+  }{
+    {http.StatusServiceUnavailable, 0, true},
+    {http.StatusGatewayTimeout, 0, true},
+    {http.StatusOK, 0, false},
+    {http.StatusServiceUnavailable, 50 * time.Millisecond, false},
+  }
+  for _, e := range tests {
+    err := TimesOut(Request{Path: "/"}, 25 * time.Millisecond).Run(NewHandlerTarget(timeout(e.Status, e.Delay)))
+    if e.Pass && err != nil {
+      t.Errorf("%v after %v: unexpected error: %v", e.Status, e.Delay, err)
+    }else if !e.Pass && err == nil {
+      t.Errorf("%v after %v: expected an error", e.Status, e.Delay)
+    }
+  }
+}
+
+func TestHandlerTargetRequest(t *testing.T) {
+  var addr, uri, host string
+  h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+    addr, uri, host = r.RemoteAddr, r.RequestURI, r.Host
+  })
+  err := Expect("Request", Request{Path: "/things?a=b"}, http.StatusOK).Run(NewHandlerTarget(h))
+  if err != nil {
+    t.Errorf("Unexpected error: %v", err)
+  }
+  if addr == "" || uri != "/things?a=b" || host == "" {
+    t.Errorf("Unexpected request: %v, %v, %v", addr, uri, host)
+  }
+}
+
+func TestServiceTarget(t *testing.T) {
+  s := rest.NewService(rest.Config{})
+  
+  // authorize requests with a token
+  s.Use(rest.HandlerFunc(func(w http.ResponseWriter, r *rest.Request, p rest.Pipeline) (interface{}, error) {
+    switch r.Header.Get("Authorization") {
+      case "":
+        return nil, rest.NewErrorf(http.StatusUnauthorized, "No credentials")
+      case "Bearer valid":
+        return p.Next(w, r)
+      default:
+        return nil, rest.NewErrorf(http.StatusForbidden, "Invalid credentials")
+    }
+  }))
+  
+  // limit requests per client address
+  count := make(map[string]int)
+  s.Use(rest.HandlerFunc(func(w http.ResponseWriter, r *rest.Request, p rest.Pipeline) (interface{}, error) {
+    if r.RemoteAddr == "" {
+      return nil, rest.NewErrorf(http.StatusInternalServerError, "No client address")
+    }
+    if count[r.RemoteAddr]++; count[r.RemoteAddr] > 2 {
+      return nil, rest.NewErrorf(http.StatusTooManyRequests, "Too many requests")
+    }
+    return p.Next(w, r)
+  }))
+  
+  c := s.Context()
+  c.HandleFunc("/things", func(w http.ResponseWriter, r *rest.Request, p rest.Pipeline) (interface{}, error) {
+    var v map[string]interface{}
+    err := httputil.UnmarshalRequestEntity(r, &v)
+    if err != nil {
+      return nil, err
+    }
+    return v, nil
+  }).Methods("POST")
+  c.HandleFunc("/slow", func(w http.ResponseWriter, r *rest.Request, p rest.Pipeline) (interface{}, error) {
+    return nil, rest.NewErrorf(http.StatusGatewayTimeout, "Timed out")
+  })
+  
+  auth := http.Header{"Authorization": []string{"Bearer valid"}}
+  target := NewServiceTarget(s)
+  
+  Suite{
+    Unauthenticated(Request{Path: "/things", Header: auth}),
+    BadCredentials(Request{Path: "/things"}, "Bearer invalid"),
+    MalformedEntity(Request{Path: "/things", Header: auth}),
+    TimesOut(Request{Path: "/slow", Header: auth}, time.Second),
+  }.Run(t, target)
+  
+  count = make(map[string]int)
+  Suite{
+    RateLimited(Request{Method: "POST", Path: "/things", Header: auth, Body: []byte(`{}`)}, 2),
+  }.Run(t, target)
+  
+  count = make(map[string]int)
+  errs := Suite{
+    RateLimited(Request{Method: "POST", Path: "/things", Header: auth, Body: []byte(`{}`)}, 3),
+  }.Check(target)
+  if len(errs) != 1 {
+    t.Errorf("Expected an error: %v", errs)
+  }
+}
@@ -37,12 +37,30 @@ type Config struct {
   ReadTimeout   time.Duration
   WriteTimeout  time.Duration
   IdleTimeout   time.Duration
+  DetachTimeout time.Duration // how long to wait for a detached request to be completed before failing with 503; zero for the default, negative to wait indefinitely
   Endpoint      string
   TraceRegexps  []*regexp.Regexp
   EntityHandler EntityHandler
   Debug         bool
 }
 
+/**
+ * The default time to wait for a detached request to be completed
+ */
+const DefaultDetachTimeout = time.Minute
+
+/**
+ * Headers which may legitimately be repeated and are therefore appended to,
+ * rather than replaced, when a response is written
+ */
+var appendHeaders = map[string]struct{}{
+  "Set-Cookie": struct{}{},
+  "Link":       struct{}{},
+  "Vary":       struct{}{},
+  "Via":        struct{}{},
+  "Warning":    struct{}{},
+}
+
 /**
  * A REST service
  */
@@ -54,6 +72,7 @@ type Service struct {
   port          string
   router        *mux.Router
   pipeline      Pipeline
+  routed        Pipeline
   traceRequests map[string]*regexp.Regexp
   entityHandler EntityHandler
   debug         bool
@@ -61,6 +80,7 @@ type Service struct {
   readTimeout   time.Duration
   writeTimeout  time.Duration
   idleTimeout   time.Duration
+  detachTimeout time.Duration
   suppress      map[string]struct{}
 }
 
@@ -75,11 +95,18 @@ func NewService(c Config) *Service {
   s.userAgent = c.UserAgent
   s.port = c.Endpoint
   s.router = mux.NewRouter()
+  s.setPipeline(nil)
   s.entityHandler = c.EntityHandler
   s.readTimeout = c.ReadTimeout
   s.writeTimeout = c.WriteTimeout
   s.idleTimeout = c.IdleTimeout
   
+  if c.DetachTimeout == 0 {
+    s.detachTimeout = DefaultDetachTimeout
+  }else{
+    s.detachTimeout = c.DetachTimeout
+  }
+  
   if c.Name == "" {
     s.name = "service"
   }else{
@@ -159,18 +186,27 @@ func (s *Service) ContextWithBasePath(p string) *Context {
  */
 func (s *Service) Use(h ...Handler) {
   if h != nil {
+    p := s.pipeline
     for _, e := range h {
-      s.pipeline = s.pipeline.Add(e)
+      p = p.Add(e)
     }
+    s.setPipeline(p)
   }
 }
 
+/**
+ * Set the service pipeline and update the routed pipeline, which is the
+ * service pipeline terminated by routing and is used to serve requests
+ */
+func (s *Service) setPipeline(p Pipeline) {
+  s.pipeline = p
+  s.routed = p.Add(HandlerFunc(s.routeRequest))
+}
+
 /**
  * Run the service (this blocks forever)
  */
 func (s *Service) Run() error {
-  s.pipeline = s.pipeline.Add(HandlerFunc(s.routeRequest))
-  
   server := &http.Server{
     Addr: s.port,
     Handler: s,
@@ -183,6 +219,14 @@ func (s *Service) Run() error {
   return server.ListenAndServe()
 }
 
+/**
+ * Obtain a handler which serves requests through the full service pipeline,
+ * including routing, without running a server; this is useful for testing.
+ */
+func (s *Service) Handler() http.Handler {
+  return s
+}
+
 /**
  * Display all routes in the service
  */
@@ -199,12 +243,57 @@ func (s *Service) DumpRoutes(w io.Writer) error {
   return nil
 }
 
+/**
+ * Generate the URL for a named route
+ */
+func (s *Service) routeURL(name string, vars ...string) (string, error) {
+  route := s.router.Get(name)
+  if route == nil {
+    return "", fmt.Errorf("No such route: %v", name)
+  }
+  u, err := route.URL(vars...)
+  if err != nil {
+    return "", err
+  }
+  return u.String(), nil
+}
+
+/**
+ * If the result is a response for a created resource, produce a copy of it
+ * with its Location header generated from the named route; the original
+ * response is not modified
+ */
+func (s *Service) resolveLocation(res interface{}) (interface{}, error) {
+  v, ok := res.(*Response)
+  if !ok {
+    return res, nil
+  }
+  c, ok := v.Entity.(createdEntity)
+  if !ok {
+    return res, nil
+  }
+  
+  loc, err := s.routeURL(c.route, c.vars...)
+  if err != nil {
+    return nil, NewErrorf(http.StatusInternalServerError, "Could not generate location: %v", err)
+  }
+  
+  h := make(http.Header)
+  for k, x := range v.Headers {
+    h[k] = append([]string(nil), x...)
+  }
+  h.Set("Location", loc)
+  
+  return &Response{v.StatusCode, h, c.entity}, nil
+}
+
 /**
  * Request handler
  */
 func (s *Service) ServeHTTP(rsp http.ResponseWriter, req *http.Request) {
   wreq := newRequest(req)
-  res, err := s.pipeline.Next(rsp, wreq)
+  res, err := s.routed.Next(rsp, wreq)
+  res, err = wreq.await(res, err, s.detachTimeout)
   if res != nil || err != nil {
     s.sendResponse(rsp, wreq, res, err)
   }
@@ -237,7 +326,13 @@ func (s *Service) sendResponse(rsp http.ResponseWriter, req *Request, res interf
 func (s *Service) sendSuccess(rsp http.ResponseWriter, req *Request, res interface{}) {
   var r int
   var e interface{}
-  var h map[string]string
+  var h http.Header
+  
+  res, err := s.resolveLocation(res)
+  if err != nil {
+    s.sendError(rsp, req, err)
+    return
+  }
   
   switch v := res.(type) {
     case *Response:
@@ -245,9 +340,11 @@ func (s *Service) sendSuccess(rsp http.ResponseWriter, req *Request, res interfa
       e = v.Entity
       h = v.Headers
     default:
-      r = http.StatusOK
       e = res
   }
+  if r == 0 {
+    r = req.defaultStatus()
+  }
   
   s.sendEntity(rsp, req, r, h, e)
 }
@@ -259,7 +356,7 @@ func (s *Service) sendError(rsp http.ResponseWriter, req *Request, err error) {
   var m string
   var r int
   var c error
-  var h map[string]string
+  var h http.Header
   
   switch v := err.(type) {
     case *Error:
@@ -292,15 +389,33 @@ func (s *Service) sendError(rsp http.ResponseWriter, req *Request, err error) {
 /**
  * Respond with an entity
  */
-func (s *Service) sendEntity(rsp http.ResponseWriter, req *Request, status int, headers map[string]string, content interface{}) {
+func (s *Service) sendEntity(rsp http.ResponseWriter, req *Request, status int, headers http.Header, content interface{}) {
   
+  // headers provided by the response replace any already written by
+  // handlers upstream, except those which are expected to be repeated
   if headers != nil {
     for k, v := range headers {
-      rsp.Header().Add(k, v)
+      k = http.CanonicalHeaderKey(k)
+      if _, ok := appendHeaders[k]; !ok {
+        rsp.Header().Del(k)
+      }
+      for _, e := range v {
+        rsp.Header().Add(k, e)
+      }
     }
   }
   if ua := s.userAgent; ua != "" {
-    rsp.Header().Add("User-Agent", ua)
+    rsp.Header().Set("User-Agent", ua)
+  }
+  
+  // some statuses must not have a body; an externally handled entity is
+  // left alone since it has already been written
+  if !statusAllowsBody(status) {
+    switch content.(type) {
+      case NoopEntity, *NoopEntity:
+      default:
+        content = nil
+    }
   }
   
   var err error
@@ -316,10 +431,24 @@ func (s *Service) sendEntity(rsp http.ResponseWriter, req *Request, status int,
   
 }
 
+/**
+ * Determine if a response with the provided status may include a body
+ */
+func statusAllowsBody(status int) bool {
+  switch {
+    case status >= 100 && status < 200:
+      return false
+    case status == http.StatusNoContent, status == http.StatusResetContent, status == http.StatusNotModified:
+      return false
+    default:
+      return true
+  }
+}
+
 /**
  * Produce a HTML error entity
  */
-func htmlError(status int, headers map[string]string, content error) Entity {
+func htmlError(status int, headers http.Header, content error) Entity {
   
   e := html.EscapeString(content.Error())
   
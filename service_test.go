@@ -0,0 +1,75 @@
+package rest
+
+import (
+  "testing"
+  "net/http"
+  "net/http/httptest"
+)
+
+func TestServiceHandlerUse(t *testing.T) {
+  s := NewService(Config{})
+  h := s.Handler()
+  
+  var n int
+  s.Use(HandlerFunc(func(w http.ResponseWriter, r *Request, p Pipeline) (interface{}, error) {
+    n++
+    return p.Next(w, r)
+  }))
+  
+  h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+  s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+  if n != 2 {
+    t.Errorf("Unexpected middleware invocations: %d", n)
+  }
+}
+
+func TestServiceSendHeaders(t *testing.T) {
+  s := NewService(Config{UserAgent: "test"})
+  
+  upstream := func() *httptest.ResponseRecorder {
+    rsp := httptest.NewRecorder()
+    rsp.Header().Set("X-Example", "upstream")
+    rsp.Header().Set("User-Agent", "upstream")
+    rsp.Header().Add("Vary", "Origin")
+    rsp.Header().Add("Set-Cookie", "upstream=1")
+    return rsp
+  }
+  check := func(rsp *httptest.ResponseRecorder, key string, expect ...string) {
+    v := rsp.Header()[key]
+    if len(v) != len(expect) {
+      t.Errorf("%v: unexpected values: %v", key, v)
+      return
+    }
+    for i, e := range expect {
+      if v[i] != e {
+        t.Errorf("%v: unexpected values: %v", key, v)
+        return
+      }
+    }
+  }
+  
+  res := NewResponse(http.StatusOK, map[string]string{"X-Example": "response", "Content-Type": "application/vnd.example+json"}, "entity")
+  res.AddHeader("Link", "<a>").AddHeader("Link", "<b>").AddHeader("Vary", "Accept")
+  res.SetCookie(&http.Cookie{Name: "a", Value: "1"}).SetCookie(&http.Cookie{Name: "b", Value: "2"})
+  
+  rsp := upstream()
+  s.sendResponse(rsp, newRequest(httptest.NewRequest("GET", "/", nil)), res, nil)
+  check(rsp, "X-Example", "response")
+  check(rsp, "User-Agent", "test")
+  check(rsp, "Content-Type", "application/vnd.example+json")
+  check(rsp, "Link", "<a>", "<b>")
+  check(rsp, "Vary", "Origin", "Accept")
+  check(rsp, "Set-Cookie", "upstream=1", "a=1", "b=2")
+  
+  err := NewErrorf(http.StatusBadRequest, "Invalid").Header("X-Example", "error").AddHeader("Link", "<a>").AddHeader("Link", "<b>")
+  
+  rsp = upstream()
+  s.sendResponse(rsp, newRequest(httptest.NewRequest("GET", "/", nil)), nil, err)
+  if rsp.Code != http.StatusBadRequest {
+    t.Errorf("Unexpected status: %v", rsp.Code)
+  }
+  check(rsp, "X-Example", "error")
+  check(rsp, "Content-Type", "application/json")
+  check(rsp, "Link", "<a>", "<b>")
+  check(rsp, "Set-Cookie", "upstream=1")
+}